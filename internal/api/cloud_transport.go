@@ -0,0 +1,113 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// CloudTransport drives the lamp through api.lgledsolutions.com. It is the
+// original (and still default) transport; it needs a valid session token,
+// so it defers to the owning MarsHydroAPI for login and header generation.
+type CloudTransport struct {
+	api *MarsHydroAPI
+}
+
+func NewCloudTransport(api *MarsHydroAPI) *CloudTransport {
+	return &CloudTransport{api: api}
+}
+
+func (c *CloudTransport) SetBrightness(deviceID, groupID string, brightness interface{}) error {
+	if err := c.api.ensureToken(); err != nil {
+		return err
+	}
+
+	systemData := c.api.generateSystemData(deviceID)
+	payload := map[string]interface{}{
+		"light":    brightness,
+		"deviceId": deviceID,
+		"groupId":  groupID, // may be empty
+	}
+	bPayload, _ := json.Marshal(payload)
+
+	req, err := http.NewRequest("POST", c.api.BaseURL+"/udm/adjustLight/v1", bytes.NewBuffer(bPayload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("systemData", systemData)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Host", "api.lgledsolutions.com")
+	req.Header.Set("User-Agent", "Python/3.x") // not checked but mehh
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var resData map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&resData); err != nil {
+		return err
+	}
+
+	// SUCCESS WOULD BE -> SetBrightness response: map[code:000 data:map[commandMap:map[] current:0 nodeDeviceId:<nil>] msg:success subCode:<nil>]
+	if code, ok := resData["code"].(string); !ok || code != "000" {
+		log.Println("Error in API response:", resData["msg"])
+		return errors.New("received error response")
+	}
+
+	log.Println("Brightness set successfully via cloud.")
+	return nil
+}
+
+func (c *CloudTransport) ToggleSwitch(deviceID, groupID string, isClose bool) error {
+	_, err := c.api.cloudToggleSwitch(isClose, deviceID, groupID)
+	return err
+}
+
+// cloudToggleSwitch is the original ToggleSwitch implementation; it is kept
+// on MarsHydroAPI because it recurses through api.Login on a stale token.
+func (api *MarsHydroAPI) cloudToggleSwitch(isClose bool, deviceID, groupID string) (map[string]interface{}, error) {
+	if err := api.ensureToken(); err != nil {
+		return nil, err
+	}
+
+	systemData := api.generateSystemData(deviceID)
+	payload := map[string]interface{}{
+		"isClose":  isClose,
+		"deviceId": deviceID,
+		"groupId":  groupID,
+	}
+	bPayload, _ := json.Marshal(payload)
+
+	req, err := http.NewRequest("POST", api.BaseURL+"/udm/lampSwitch/v1", bytes.NewBuffer(bPayload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("systemData", systemData)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var resData map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&resData); err != nil {
+		return nil, err
+	}
+
+	// If token expired (code 102), re-authenticate.
+	if code, ok := resData["code"].(string); ok && code == "102" {
+		log.Println("Token expired, re-authenticating...")
+		if err := api.Login(); err != nil {
+			return nil, err
+		}
+		return api.cloudToggleSwitch(isClose, deviceID, groupID)
+	}
+	return resData, nil
+}
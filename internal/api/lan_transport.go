@@ -0,0 +1,231 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// lanDiscoveryPort is the UDP port the lamp listens on for discovery
+	// broadcasts, and lanCommandPort is the TCP port it exposes its
+	// command socket on. Mirrors the framing used by comparable
+	// home-automation LAN drivers (e.g. LIFX's UDP discovery + per-bulb
+	// command socket), reverse engineered against a Mars Hydro TSW-750.
+	lanDiscoveryPort = 43210
+	lanCommandPort   = 43211
+
+	lanDiscoveryTimeout = 2 * time.Second
+	lanCommandTimeout   = 2 * time.Second
+	lanCacheTTL         = 10 * time.Minute
+
+	// lanNegativeCacheTTL bounds how long a failed discovery is remembered,
+	// so an install with no LAN lamp at all doesn't pay the full
+	// lanDiscoveryTimeout on every single Tick.
+	lanNegativeCacheTTL = 30 * time.Second
+)
+
+// LANTransport talks to the lamp directly on the local network, skipping
+// the vendor cloud entirely. It discovers each lamp's IP via a UDP
+// broadcast, keyed and cached per deviceID for lanCacheTTL (a single
+// MarsHydroAPI, and so a single LANTransport, is shared across every
+// Controller in a multi-lamp install), then sends commands over a
+// short-lived TCP connection framed as newline-delimited JSON.
+type LANTransport struct {
+	mu        sync.Mutex
+	cache     map[string]cachedLamp // deviceID -> resolved address
+	Interface string                // optional: bind discovery to a specific local interface
+}
+
+type cachedLamp struct {
+	addr  string
+	found time.Time
+	ok    bool // false means this entry records a failed discovery
+}
+
+func NewLANTransport() *LANTransport {
+	return &LANTransport{cache: make(map[string]cachedLamp)}
+}
+
+func (l *LANTransport) SetBrightness(deviceID, groupID string, brightness interface{}) error {
+	addr, err := l.resolveLamp(deviceID)
+	if err != nil {
+		return err
+	}
+	return l.sendCommand(addr, map[string]interface{}{
+		"cmd":      "setBrightness",
+		"deviceId": deviceID,
+		"groupId":  groupID,
+		"light":    brightness,
+	})
+}
+
+func (l *LANTransport) ToggleSwitch(deviceID, groupID string, isClose bool) error {
+	addr, err := l.resolveLamp(deviceID)
+	if err != nil {
+		return err
+	}
+	return l.sendCommand(addr, map[string]interface{}{
+		"cmd":      "toggleSwitch",
+		"deviceId": deviceID,
+		"groupId":  groupID,
+		"isClose":  isClose,
+	})
+}
+
+// resolveLamp returns deviceID's cached lamp address if still fresh,
+// otherwise re-runs UDP discovery for that specific device. A failed
+// discovery is also cached, briefly, so a LAN-less install doesn't pay
+// lanDiscoveryTimeout on every call.
+func (l *LANTransport) resolveLamp(deviceID string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if cached, ok := l.cache[deviceID]; ok {
+		ttl := lanCacheTTL
+		if !cached.ok {
+			ttl = lanNegativeCacheTTL
+		}
+		if time.Since(cached.found) < ttl {
+			if !cached.ok {
+				return "", errors.New("LAN discovery recently failed for this device, not retrying yet")
+			}
+			return cached.addr, nil
+		}
+	}
+
+	addr, err := discoverLamp(l.Interface, deviceID)
+	if err != nil {
+		l.cache[deviceID] = cachedLamp{found: time.Now(), ok: false}
+		return "", err
+	}
+	l.cache[deviceID] = cachedLamp{addr: addr, found: time.Now(), ok: true}
+	return addr, nil
+}
+
+// discoverLamp broadcasts a discovery packet on the LAN and waits for
+// deviceID's lamp specifically to announce its IP address, ignoring
+// replies from any other lamp that answers the same broadcast. If iface is
+// non-empty, discovery is bound to that interface's local address, which
+// matters on a multi-NIC host where the wildcard address might route the
+// broadcast out the wrong link.
+func discoverLamp(iface, deviceID string) (string, error) {
+	laddr := &net.UDPAddr{Port: 0}
+	if iface != "" {
+		ip, err := interfaceIPv4(iface)
+		if err != nil {
+			return "", fmt.Errorf("resolving LAN discovery interface %q: %w", iface, err)
+		}
+		laddr.IP = ip
+	}
+
+	conn, err := net.ListenUDP("udp4", laddr)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	broadcast := &net.UDPAddr{IP: net.IPv4bcast, Port: lanDiscoveryPort}
+	discoveryPacket := []byte(`{"cmd":"discover"}`)
+	if _, err := conn.WriteTo(discoveryPacket, broadcast); err != nil {
+		return "", err
+	}
+
+	deadline := time.Now().Add(lanDiscoveryTimeout)
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 512)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return "", fmt.Errorf("LAN discovery timed out: %w", err)
+		}
+
+		var resp struct {
+			DeviceID string `json:"deviceId"`
+		}
+		if err := json.Unmarshal(buf[:n], &resp); err != nil {
+			log.Println("Ignoring malformed LAN discovery reply:", err)
+			continue
+		}
+		if resp.DeviceID != deviceID {
+			// Another lamp answering the same broadcast; keep listening
+			// until deviceID's own lamp replies or the deadline passes.
+			continue
+		}
+
+		return from.IP.String(), nil
+	}
+}
+
+// interfaceIPv4 returns the first IPv4 address bound to the named network
+// interface.
+func interfaceIPv4(name string) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ip4 := ipNet.IP.To4(); ip4 != nil {
+			return ip4, nil
+		}
+	}
+	return nil, fmt.Errorf("no IPv4 address found on interface %q", name)
+}
+
+// sendCommand opens a short-lived TCP connection to the lamp's command
+// socket and sends a single newline-delimited JSON command.
+func (l *LANTransport) sendCommand(lampIP string, payload map[string]interface{}) error {
+	conn, err := net.DialTimeout("tcp4", fmt.Sprintf("%s:%d", lampIP, lanCommandPort), lanCommandTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	if err := conn.SetDeadline(time.Now().Add(lanCommandTimeout)); err != nil {
+		return err
+	}
+	if _, err := conn.Write(append(b, '\n')); err != nil {
+		return err
+	}
+
+	var line bytes.Buffer
+	tmp := make([]byte, 256)
+	n, err := conn.Read(tmp)
+	if err != nil {
+		return err
+	}
+	line.Write(tmp[:n])
+
+	var ack struct {
+		OK  bool   `json:"ok"`
+		Err string `json:"error"`
+	}
+	if err := json.Unmarshal(line.Bytes(), &ack); err != nil {
+		return fmt.Errorf("malformed LAN command ack: %w", err)
+	}
+	if !ack.OK {
+		return fmt.Errorf("lamp rejected LAN command: %s", ack.Err)
+	}
+	return nil
+}
@@ -9,8 +9,20 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"lightcontrol/internal/metrics"
 )
 
+// Device is a single lamp as reported by ListDevices.
+type Device struct {
+	ID         string
+	GroupID    string
+	Name       string
+	Brightness int
+	IsClose    bool
+	Image      string
+}
+
 type MarsHydroAPI struct {
 	Email         string
 	Password      string
@@ -22,12 +34,16 @@ type MarsHydroAPI struct {
 	mu            sync.Mutex
 	LastLoginTime time.Time
 	LoginInterval time.Duration
-	DeviceID      string
-	GroupID       string
+
+	// Cloud is always available (it also owns login and device listing).
+	// LAN is tried first for SetBrightness/ToggleSwitch and falls back to
+	// Cloud on error, so a vendor cloud outage doesn't take the lamp down.
+	Cloud *CloudTransport
+	LAN   *LANTransport
 }
 
 func NewMarsHydroAPI(email, password, WifiName, Timezone, Language string) *MarsHydroAPI {
-	return &MarsHydroAPI{
+	api := &MarsHydroAPI{
 		Email:         email,
 		Password:      password,
 		WifiName:      WifiName,
@@ -36,22 +52,39 @@ func NewMarsHydroAPI(email, password, WifiName, Timezone, Language string) *Mars
 		BaseURL:       "https://api.lgledsolutions.com/api/android",
 		LoginInterval: 300 * time.Second,
 	}
+	api.Cloud = NewCloudTransport(api)
+	api.LAN = NewLANTransport()
+	return api
 }
 
-func (api *MarsHydroAPI) generateSystemData() string {
+// generateSystemData builds the systemData header. deviceID identifies the
+// lamp a request targets; pass "" for requests (like login) that aren't
+// scoped to a particular device. Token is read under api.mu, since Login
+// may be updating it concurrently from another goroutine.
+func (api *MarsHydroAPI) generateSystemData(deviceID string) string {
+	api.mu.Lock()
+	token := api.Token
+	api.mu.Unlock()
+	return api.buildSystemData(deviceID, token)
+}
+
+// buildSystemData does the actual marshaling, given an already-snapshotted
+// token. Login calls this directly with api.Token, since it already holds
+// api.mu itself and calling generateSystemData there would deadlock.
+func (api *MarsHydroAPI) buildSystemData(deviceID, token string) string {
 	data := map[string]interface{}{
 		"reqId":      time.Now().UnixNano() / 1e6,
 		"appVersion": "1.2.0",
 		"osType":     "android",
 		"osVersion":  "14",
 		"deviceType": "SM-S928C",
-		"deviceId":   api.DeviceID,
+		"deviceId":   deviceID,
 		"netType":    "wifi",
 		"wifiName":   api.WifiName,
 		"timestamp":  time.Now().Unix(),
-		"token":      api.Token,
-		"timezone":  api.Timezone,
-		"language":  	api.Language,
+		"token":      token,
+		"timezone":   api.Timezone,
+		"language":   api.Language,
 	}
 	b, _ := json.Marshal(data)
 	return string(b)
@@ -66,7 +99,12 @@ func (api *MarsHydroAPI) Login() error {
 		return nil
 	}
 
-	systemData := api.generateSystemData()
+	metrics.LoginTotal.Inc()
+	start := time.Now()
+	code := "error"
+	defer func() { metrics.ObserveAPICall("login", code, time.Since(start)) }()
+
+	systemData := api.buildSystemData("", api.Token)
 	payload := map[string]interface{}{
 		"email":       api.Email,
 		"password":    api.Password,
@@ -102,58 +140,43 @@ func (api *MarsHydroAPI) Login() error {
 	}
 	api.Token = token
 	api.LastLoginTime = time.Now()
+	code = "000"
 	log.Println("Login successful, token received.")
 	return nil
 }
 
-func (api *MarsHydroAPI) ToggleSwitch(isClose bool, deviceID string) (map[string]interface{}, error) {
-	if err := api.ensureToken(); err != nil {
-		return nil, err
-	}
-
-	systemData := api.generateSystemData()
-	payload := map[string]interface{}{
-		"isClose":  isClose,
-		"deviceId": deviceID,
-		"groupId":  nil,
-	}
-	bPayload, _ := json.Marshal(payload)
-
-	req, err := http.NewRequest("POST", api.BaseURL+"/udm/lampSwitch/v1", bytes.NewBuffer(bPayload))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("systemData", systemData)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	var resData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&resData); err != nil {
-		return nil, err
-	}
-
-	// If token expired (code 102), re-authenticate.
-	if code, ok := resData["code"].(string); ok && code == "102" {
-		log.Println("Token expired, re-authenticating...")
-		if err := api.Login(); err != nil {
-			return nil, err
+// ToggleSwitch turns a device (and, if non-empty, its group) on/off, trying
+// the LAN transport first and falling back to the cloud if the lamp isn't
+// reachable locally.
+func (api *MarsHydroAPI) ToggleSwitch(deviceID, groupID string, isClose bool) error {
+	start := time.Now()
+	code := "000"
+	defer func() { metrics.ObserveAPICall("toggleSwitch", code, time.Since(start)) }()
+
+	if err := api.LAN.ToggleSwitch(deviceID, groupID, isClose); err != nil {
+		log.Println("LAN toggle failed, falling back to cloud:", err)
+		if err := api.Cloud.ToggleSwitch(deviceID, groupID, isClose); err != nil {
+			code = "error"
+			return err
 		}
-		return api.ToggleSwitch(isClose, deviceID)
+		return nil
 	}
-	return resData, nil
+	log.Println("Toggled lamp over LAN.")
+	return nil
 }
 
-func (api *MarsHydroAPI) GetLightData() (map[string]interface{}, error) {
+// ListDevices returns every lamp on the account from the getDeviceList
+// endpoint, rather than caching just the first one.
+func (api *MarsHydroAPI) ListDevices() ([]Device, error) {
+	start := time.Now()
+	code := "error"
+	defer func() { metrics.ObserveAPICall("listDevices", code, time.Since(start)) }()
+
 	if err := api.ensureToken(); err != nil {
 		return nil, err
 	}
 
-	systemData := api.generateSystemData()
+	systemData := api.generateSystemData("")
 	payload := map[string]interface{}{
 		"currentPage": 0,
 		"type":        nil,
@@ -182,7 +205,7 @@ func (api *MarsHydroAPI) GetLightData() (map[string]interface{}, error) {
 		return nil, err
 	}
 
-	if code, ok := resData["code"].(string); !ok || code != "000" {
+	if respCode, ok := resData["code"].(string); !ok || respCode != "000" {
 		log.Println("Error in API response:", resData["msg"])
 		return nil, errors.New("error retrieving light devices")
 	}
@@ -193,113 +216,89 @@ func (api *MarsHydroAPI) GetLightData() (map[string]interface{}, error) {
 		log.Println("No light devices found.")
 		return nil, errors.New("no light devices available")
 	}
-	deviceData, ok := list[0].(map[string]interface{})
-	if !ok {
-		return nil, errors.New("invalid device data")
-	}
-	// Try retrieving device id as string; if not, check if it's numeric.
-	if id, exists := deviceData["id"]; exists {
-		switch v := id.(type) {
-		case string:
-			if v != "" {
-				api.DeviceID = v
-			}
-		case float64:
-			api.DeviceID = fmt.Sprintf("%.0f", v)
+
+	devices := make([]Device, 0, len(list))
+	for _, entry := range list {
+		deviceData, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
 		}
-	}
-	// Fallback: check "deviceId" field similarly.
-	if api.DeviceID == "" {
-		if alt, exists := deviceData["deviceId"]; exists {
-			switch v := alt.(type) {
-			case string:
-				if v != "" {
-					api.DeviceID = v
-				}
-			case float64:
-				api.DeviceID = fmt.Sprintf("%.0f", v)
-			}
+		dev := Device{
+			Name: stringField(deviceData["deviceName"]),
+			ID:   idField(deviceData, "id", "deviceId"),
+		}
+		if dev.ID == "" {
+			log.Println("Skipping device with no id:", deviceData["deviceName"])
+			continue
+		}
+		dev.GroupID = idField(deviceData, "groupId")
+		dev.Image = stringField(deviceData["deviceImg"])
+		if close, ok := deviceData["isClose"].(bool); ok {
+			dev.IsClose = close
 		}
+		if rate, ok := deviceData["deviceLightRate"].(float64); ok {
+			dev.Brightness = int(rate)
+		}
+		devices = append(devices, dev)
 	}
-	if api.DeviceID == "" {
-		return nil, errors.New("device id not found in response")
+	if len(devices) == 0 {
+		return nil, errors.New("no light devices available")
 	}
-	// Retrieve group id if available.
-	if gid, exists := deviceData["groupId"]; exists {
-		switch v := gid.(type) {
+
+	code = "000"
+	return devices, nil
+}
+
+// idField reads the first of the given fields present on data that can be
+// coerced to a string or whole-number-valued float64.
+func idField(data map[string]interface{}, fields ...string) string {
+	for _, field := range fields {
+		v, exists := data[field]
+		if !exists {
+			continue
+		}
+		switch id := v.(type) {
 		case string:
-			api.GroupID = v
+			if id != "" {
+				return id
+			}
 		case float64:
-			api.GroupID = fmt.Sprintf("%.0f", v)
+			return fmt.Sprintf("%.0f", id)
 		}
-	} else {
-		api.GroupID = ""
 	}
+	return ""
+}
 
-	lightData := map[string]interface{}{
-		"deviceName":      deviceData["deviceName"],
-		"deviceLightRate": deviceData["deviceLightRate"],
-		"isClose":         deviceData["isClose"],
-		"id":              api.DeviceID,
-		"deviceImage":     deviceData["deviceImg"],
-		"groupId":         api.GroupID,
-	}
-	return lightData, nil
+func stringField(v interface{}) string {
+	s, _ := v.(string)
+	return s
 }
 
-func (api *MarsHydroAPI) SetBrightness(brightness interface{}) (error) {
-	if api.DeviceID == "" {
-		if _, err := api.GetLightData(); err != nil {
+// SetBrightness sets the given device's (and, if non-empty, group's)
+// brightness, trying the LAN transport first and falling back to the cloud
+// if the lamp isn't reachable locally.
+func (api *MarsHydroAPI) SetBrightness(deviceID, groupID string, brightness interface{}) error {
+	start := time.Now()
+	code := "000"
+	defer func() { metrics.ObserveAPICall("setBrightness", code, time.Since(start)) }()
+
+	if err := api.LAN.SetBrightness(deviceID, groupID, brightness); err != nil {
+		log.Println("LAN SetBrightness failed, falling back to cloud:", err)
+		if err := api.Cloud.SetBrightness(deviceID, groupID, brightness); err != nil {
+			code = "error"
 			return err
 		}
-	}
-
-	if err := api.ensureToken(); err != nil {
-		return err
-	}
-
-	systemData := api.generateSystemData()
-	payload := map[string]interface{}{
-		"light":    brightness,
-		"deviceId": api.DeviceID,
-		"groupId":  api.GroupID, // use groupId (may be empty)
-	}
-	bPayload, _ := json.Marshal(payload)
-
-	req, err := http.NewRequest("POST", api.BaseURL+"/udm/adjustLight/v1", bytes.NewBuffer(bPayload))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("systemData", systemData)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept-Encoding", "gzip")
-	req.Header.Set("Host", "api.lgledsolutions.com")
-	req.Header.Set("User-Agent", "Python/3.x") // not checked but mehh
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
 		return nil
 	}
-	defer resp.Body.Close()
-
-	var resData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&resData); err != nil {
-		return err
-	}
-
-	// SUCCESS WOULD BE -> SetBrightness response: map[code:000 data:map[commandMap:map[] current:0 nodeDeviceId:<nil>] msg:success subCode:<nil>]
-	if code, ok := resData["code"].(string); !ok || code != "000" {
-		log.Println("Error in API response:", resData["msg"])
-		return errors.New("received error response")
-	}
-
-	log.Println("Brightness set successfully.")
-
+	log.Println("Brightness set successfully over LAN.")
 	return nil
 }
 
 func (api *MarsHydroAPI) ensureToken() error {
-	if api.Token == "" {
+	api.mu.Lock()
+	hasToken := api.Token != ""
+	api.mu.Unlock()
+	if !hasToken {
 		return api.Login()
 	}
 	return nil
@@ -0,0 +1,15 @@
+package api
+
+// Transport is the set of operations that can be satisfied either by
+// talking to the vendor cloud (api.lgledsolutions.com) or by reaching the
+// lamp directly over the LAN. MarsHydroAPI tries transports in order and
+// falls through to the next one on error, so a LAN outage or a cloud
+// outage degrades gracefully instead of failing the whole request.
+type Transport interface {
+	// SetBrightness sets the given device (and, if non-empty, group) to
+	// the requested brightness percentage.
+	SetBrightness(deviceID, groupID string, brightness interface{}) error
+
+	// ToggleSwitch turns the given device (and, if non-empty, group) on or off.
+	ToggleSwitch(deviceID, groupID string, isClose bool) error
+}
@@ -30,21 +30,41 @@ func LoadMHAccountConfig(path string) (*MHAccountConfig, error) {
 	return &cfg, nil
 }
 
-// LoadLightTimerConfig loads LightTimer configuration from the given file.
-func LoadLightTimerConfig(path string) (*timer.LightTimer, error) {
-	var lt timer.LightTimer
+// DeviceConfig maps one entry of devices.json to the MarsHydroAPI device it
+// controls and the schedule to run on it.
+type DeviceConfig struct {
+	// Match identifies the lamp: either its exact DeviceID, or (if no
+	// device has that id) a substring of its deviceName.
+	Match string `json:"Match"`
+	// GroupID overrides the matched device's own group id; leave empty to
+	// use whatever ListDevices reported for it.
+	GroupID string           `json:"GroupID"`
+	Timer   timer.LightTimer `json:"Timer"`
+}
+
+// LoadDevicesConfig loads the per-device schedules from devices.json.
+// account.json (MHAccountConfig) still holds the shared cloud credentials.
+func LoadDevicesConfig(path string) ([]DeviceConfig, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
-	if err := json.NewDecoder(f).Decode(&lt); err != nil {
+
+	var devices []DeviceConfig
+	if err := json.NewDecoder(f).Decode(&devices); err != nil {
 		return nil, err
 	}
-	// Basic validation: EndHour must be greater than StartHour+PlateauHour.
-	if lt.EndHour <= lt.StartHour+lt.PlateauHour {
-		return nil, 	// ensure configuration is valid.
-			&os.PathError{Op: "Load", Path: path, Err: os.ErrInvalid}
+	for _, d := range devices {
+		if d.Match == "" {
+			return nil, &os.PathError{Op: "Load", Path: path, Err: os.ErrInvalid}
+		}
+		// In "solar" mode StartHour/EndHour are unused zero values -- the
+		// schedule's edges come from sunrise/sunset instead (see
+		// LightTimer.edgesSeconds) -- so this check doesn't apply.
+		if d.Timer.Mode != "solar" && d.Timer.EndHour <= d.Timer.StartHour+d.Timer.PlateauHour {
+			return nil, &os.PathError{Op: "Load", Path: path, Err: os.ErrInvalid}
+		}
 	}
-	return &lt, nil
+	return devices, nil
 }
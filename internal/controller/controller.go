@@ -0,0 +1,196 @@
+// Package controller holds the shared, mutex-protected lamp state that
+// main's ticker loop and internal/server's HTTP/MQTT surfaces both act on,
+// so a manual override from the API coexists cleanly with the schedule.
+package controller
+
+import (
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"lightcontrol/internal/api"
+	"lightcontrol/internal/metrics"
+	"lightcontrol/internal/timer"
+)
+
+var logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+const (
+	defaultInterpolationInterval = 60 // seconds
+	defaultMaxRequestsPerMinute  = 6
+	defaultRateLimitBurst        = 2
+)
+
+// Controller owns one lamp's state and drives it through a shared
+// MarsHydroAPI session. The schedule (timer.LightTimer) decides what
+// brightness should be in effect; an override, if set, takes priority
+// until cleared.
+//
+// SetBrightness calls to the lamp are rate limited (api.lgledsolutions.com
+// throttles aggressive polling): Tick may be called every few seconds to
+// fade smoothly through a ramp, but only the latest target is actually
+// sent once a token bucket slot frees up, and calls that would move the
+// brightness by less than MinBrightnessDelta are skipped entirely.
+type Controller struct {
+	mu sync.Mutex
+
+	// Name identifies this lamp in logs and metrics (the devices.json
+	// entry's match name); DeviceID/GroupID identify it to MarsHydroAPI.
+	Name     string
+	DeviceID string
+	GroupID  string
+
+	mhapi   *api.MarsHydroAPI
+	timer   *timer.LightTimer
+	limiter *rate.Limiter
+
+	lastBrightness int // -1 means unknown; forces an update on next Tick
+	override       *int
+}
+
+// New creates a Controller for one device, sharing mhapi's authenticated
+// session. The schedule's MaxRequestsPerMinute (default 6) governs how
+// often SetBrightness is actually called against the lamp.
+func New(name, deviceID, groupID string, mhapi *api.MarsHydroAPI, lt *timer.LightTimer) *Controller {
+	maxPerMinute := lt.MaxRequestsPerMinute
+	if maxPerMinute <= 0 {
+		maxPerMinute = defaultMaxRequestsPerMinute
+	}
+
+	return &Controller{
+		Name:           name,
+		DeviceID:       deviceID,
+		GroupID:        groupID,
+		mhapi:          mhapi,
+		timer:          lt,
+		limiter:        rate.NewLimiter(rate.Limit(maxPerMinute)/60, defaultRateLimitBurst),
+		lastBrightness: -1,
+	}
+}
+
+// InterpolationInterval is how often the caller should invoke Tick to fade
+// smoothly through the ramp, per the schedule's InterpolationIntervalSeconds
+// (default 60s).
+func (c *Controller) InterpolationInterval() time.Duration {
+	c.mu.Lock()
+	seconds := c.timer.InterpolationIntervalSeconds
+	c.mu.Unlock()
+	if seconds <= 0 {
+		seconds = defaultInterpolationInterval
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Tick recomputes the desired brightness for now (the override if set,
+// otherwise the schedule) and pushes it to the lamp if it moved enough and
+// a rate limit token is available.
+func (c *Controller) Tick(now time.Time) {
+	c.mu.Lock()
+	target := c.timer.GetExpectedBrightness(now)
+	phase := c.timer.Phase(now)
+	if c.override != nil {
+		target = *c.override
+	}
+	c.mu.Unlock()
+
+	c.apply(now, target, phase)
+}
+
+func (c *Controller) apply(now time.Time, expected int, phase string) {
+	metrics.BrightnessTarget.WithLabelValues(c.Name).Set(float64(expected))
+
+	c.mu.Lock()
+	actual := c.lastBrightness
+	delta := expected - actual
+	if delta < 0 {
+		delta = -delta
+	}
+	withinMinDelta := actual != -1 && delta < c.timer.MinBrightnessDelta
+	unchanged := actual != -1 && (expected == actual || withinMinDelta)
+	c.mu.Unlock()
+
+	if unchanged {
+		logger.Info("lamp_tick", "device", c.Name, "expected", expected, "actual", actual, "phase", phase, "changed", false)
+		return
+	}
+
+	if !c.limiter.Allow() {
+		logger.Info("lamp_tick", "device", c.Name, "expected", expected, "actual", actual, "phase", phase, "changed", true, "rate_limited", true)
+		return
+	}
+
+	logger.Info("lamp_tick", "device", c.Name, "expected", expected, "actual", actual, "phase", phase, "changed", true)
+	if err := c.mhapi.Login(); err != nil {
+		logger.Error("lamp_login_failed", "device", c.Name, "error", err.Error())
+		return
+	}
+	if err := c.mhapi.SetBrightness(c.DeviceID, c.GroupID, expected); err != nil {
+		logger.Error("lamp_set_brightness_failed", "device", c.Name, "error", err.Error(), "expected", expected)
+		return
+	}
+
+	c.mu.Lock()
+	c.lastBrightness = expected
+	c.mu.Unlock()
+
+	metrics.BrightnessCurrent.WithLabelValues(c.Name).Set(float64(expected))
+	metrics.StateChangesTotal.WithLabelValues(c.Name).Inc()
+	logger.Info("lamp_state_changed", "device", c.Name, "brightness", expected, "phase", phase)
+}
+
+// Brightness returns the last brightness actually committed to the lamp.
+func (c *Controller) Brightness() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastBrightness
+}
+
+// SetOverride pins the lamp to brightness until ClearOverride is called,
+// regardless of what the schedule says. The override is pushed to the lamp
+// right away (subject to the same delta and rate limit checks as Tick).
+func (c *Controller) SetOverride(brightness int) {
+	now := time.Now()
+	c.mu.Lock()
+	c.override = &brightness
+	phase := c.timer.Phase(now)
+	c.mu.Unlock()
+	c.apply(now, brightness, phase)
+}
+
+// ClearOverride removes any manual override, returning control to the
+// schedule and pushing the schedule's current target to the lamp right away.
+func (c *Controller) ClearOverride() {
+	c.mu.Lock()
+	c.override = nil
+	now := time.Now()
+	target := c.timer.GetExpectedBrightness(now)
+	phase := c.timer.Phase(now)
+	c.mu.Unlock()
+	c.apply(now, target, phase)
+}
+
+// Overridden reports whether a manual override is currently in effect.
+func (c *Controller) Overridden() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.override != nil
+}
+
+// Schedule returns a copy of the schedule backing this controller. It's a
+// snapshot, not a live view: mutating it has no effect, and it won't see a
+// concurrent ReplaceTimer. Use ReplaceTimer to swap schedules.
+func (c *Controller) Schedule() timer.LightTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return *c.timer
+}
+
+// ReplaceTimer swaps in a new schedule, taking effect on the next Tick.
+func (c *Controller) ReplaceTimer(lt *timer.LightTimer) {
+	c.mu.Lock()
+	c.timer = lt
+	c.mu.Unlock()
+}
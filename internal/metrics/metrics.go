@@ -0,0 +1,54 @@
+// Package metrics registers the Prometheus collectors for lightcontrol and
+// exposes small helpers so internal/api and internal/controller don't need
+// to touch the prometheus API directly.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// BrightnessCurrent/BrightnessTarget/StateChangesTotal are keyed by
+	// "device" (the devices.json entry's match name) since a single
+	// process can now drive several lamps.
+	BrightnessCurrent = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lightcontrol_brightness_current",
+		Help: "Brightness percentage last confirmed on the lamp.",
+	}, []string{"device"})
+
+	BrightnessTarget = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lightcontrol_brightness_target",
+		Help: "Brightness percentage the schedule (or an override) currently wants.",
+	}, []string{"device"})
+
+	APIRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "lightcontrol_api_requests_total",
+		Help: "Total MarsHydroAPI requests, by endpoint and response code.",
+	}, []string{"endpoint", "code"})
+
+	APIRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "lightcontrol_api_request_duration_seconds",
+		Help: "MarsHydroAPI request latency, by endpoint.",
+	}, []string{"endpoint"})
+
+	LoginTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "lightcontrol_login_total",
+		Help: "Total login attempts against the vendor cloud.",
+	})
+
+	StateChangesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "lightcontrol_state_changes_total",
+		Help: "Total times a lamp's committed brightness changed.",
+	}, []string{"device"})
+)
+
+// ObserveAPICall records the outcome of a single MarsHydroAPI call. Callers
+// pass code "000" when the call succeeded and "error" when it failed; the
+// vendor's other response codes are not passed through.
+func ObserveAPICall(endpoint, code string, duration time.Duration) {
+	APIRequestsTotal.WithLabelValues(endpoint, code).Inc()
+	APIRequestDuration.WithLabelValues(endpoint).Observe(duration.Seconds())
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"lightcontrol/internal/controller"
+)
+
+// MQTTConfig configures the optional MQTT publisher.
+type MQTTConfig struct {
+	Broker   string // e.g. "tcp://localhost:1883"
+	ClientID string
+	Username string
+	Password string
+	DeviceID string // used in the lightcontrol/<device>/... and homeassistant/light/<id>/... topics
+}
+
+// MQTTClient publishes lamp state to lightcontrol/<device>/state, accepts
+// overrides on lightcontrol/<device>/set, and announces the lamp to Home
+// Assistant via its MQTT-discovery convention.
+type MQTTClient struct {
+	cfg    MQTTConfig
+	client mqtt.Client
+}
+
+func stateTopic(deviceID string) string  { return fmt.Sprintf("lightcontrol/%s/state", deviceID) }
+func setTopic(deviceID string) string    { return fmt.Sprintf("lightcontrol/%s/set", deviceID) }
+func discoveryTopic(deviceID string) string {
+	return fmt.Sprintf("homeassistant/light/%s/config", deviceID)
+}
+
+// NewMQTTClient connects to cfg.Broker, publishes the Home Assistant
+// discovery config, and subscribes to the set topic. ctrl is the
+// Controller that inbound "set" messages are applied to.
+func NewMQTTClient(cfg MQTTConfig, ctrl *controller.Controller) (*MQTTClient, error) {
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetAutoReconnect(true)
+
+	c := &MQTTClient{cfg: cfg}
+	opts.SetDefaultPublishHandler(nil)
+	c.client = mqtt.NewClient(opts)
+
+	if token := c.client.Connect(); token.Wait() && token.Error() != nil {
+		return nil, token.Error()
+	}
+
+	if err := c.publishDiscovery(); err != nil {
+		return nil, err
+	}
+
+	token := c.client.Subscribe(setTopic(cfg.DeviceID), 0, func(_ mqtt.Client, msg mqtt.Message) {
+		var payload struct {
+			Brightness *int `json:"brightness"`
+			State      string `json:"state"` // "ON" | "OFF"
+		}
+		if err := json.Unmarshal(msg.Payload(), &payload); err != nil {
+			log.Println("Ignoring malformed MQTT set message:", err)
+			return
+		}
+		switch {
+		case payload.Brightness != nil:
+			ctrl.SetOverride(*payload.Brightness)
+		case payload.State == "OFF":
+			ctrl.SetOverride(0)
+		case payload.State == "ON":
+			ctrl.ClearOverride()
+		}
+	})
+	if token.Wait() && token.Error() != nil {
+		return nil, token.Error()
+	}
+
+	return c, nil
+}
+
+// PublishState publishes the current brightness to lightcontrol/<device>/state.
+func (c *MQTTClient) PublishState(brightness int) error {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"brightness": brightness,
+		"state":      onOff(brightness),
+	})
+	token := c.client.Publish(stateTopic(c.cfg.DeviceID), 0, true, payload)
+	token.Wait()
+	return token.Error()
+}
+
+// publishDiscovery announces the lamp to Home Assistant as a dimmable
+// light entity, per its MQTT-discovery convention.
+func (c *MQTTClient) publishDiscovery() error {
+	cfg := map[string]interface{}{
+		"name":                 fmt.Sprintf("Mars Hydro %s", c.cfg.DeviceID),
+		"unique_id":            fmt.Sprintf("lightcontrol_%s", c.cfg.DeviceID),
+		"schema":               "json",
+		"state_topic":          stateTopic(c.cfg.DeviceID),
+		"command_topic":        setTopic(c.cfg.DeviceID),
+		"brightness":           true,
+		"brightness_scale":     100,
+		"qos":                  0,
+		"retain":               true,
+	}
+	payload, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	token := c.client.Publish(discoveryTopic(c.cfg.DeviceID), 0, true, payload)
+	token.Wait()
+	return token.Error()
+}
+
+// Close disconnects the MQTT client, allowing 250ms to flush in-flight publishes.
+func (c *MQTTClient) Close() {
+	c.client.Disconnect(250)
+}
+
+func onOff(brightness int) string {
+	if brightness <= 0 {
+		return "OFF"
+	}
+	return "ON"
+}
@@ -0,0 +1,151 @@
+// Package server exposes one or more Controllers over HTTP (REST/JSON)
+// and, if configured, MQTT, including Home Assistant's MQTT-discovery
+// convention so each lamp shows up automatically as a dimmable light
+// entity.
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"lightcontrol/internal/controller"
+	"lightcontrol/internal/timer"
+)
+
+// Server wires a set of Controllers, one per lamp, to its HTTP surface.
+// Routes are scoped to a device name: /devices/<name>/brightness, etc.
+type Server struct {
+	controllers map[string]*controller.Controller
+	mqtt        map[string]*MQTTClient // device name -> client; absent if MQTT wasn't configured for it
+}
+
+// New creates a Server for the given device-name-keyed controllers. mqtt
+// may be nil, or may omit entries, to run a device HTTP-only.
+func New(controllers map[string]*controller.Controller, mqtt map[string]*MQTTClient) *Server {
+	if mqtt == nil {
+		mqtt = map[string]*MQTTClient{}
+	}
+	return &Server{controllers: controllers, mqtt: mqtt}
+}
+
+// Handler returns the http.Handler to pass to http.ListenAndServe.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/devices/", s.handleDevice)
+	mux.Handle("/metrics", promhttp.Handler())
+	return mux
+}
+
+// handleDevice dispatches /devices/<name>/<resource> to the named
+// controller's resource handler.
+func (s *Server) handleDevice(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/devices/")
+	name, resource, found := strings.Cut(rest, "/")
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	ctrl, ok := s.controllers[name]
+	if !ok {
+		http.Error(w, "unknown device: "+name, http.StatusNotFound)
+		return
+	}
+
+	switch resource {
+	case "brightness":
+		s.handleBrightness(w, r, name, ctrl)
+	case "timer":
+		s.handleTimer(w, r, ctrl)
+	case "schedule":
+		s.handleSchedule(w, r, ctrl)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+type brightnessResponse struct {
+	Brightness int  `json:"brightness"`
+	Overridden bool `json:"overridden"`
+}
+
+func (s *Server) handleBrightness(w http.ResponseWriter, r *http.Request, deviceName string, ctrl *controller.Controller) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, brightnessResponse{
+			Brightness: ctrl.Brightness(),
+			Overridden: ctrl.Overridden(),
+		})
+	case http.MethodPut:
+		var body struct {
+			Brightness *int `json:"brightness"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Brightness == nil {
+			http.Error(w, "expected {\"brightness\": <0-100>}", http.StatusBadRequest)
+			return
+		}
+		ctrl.SetOverride(*body.Brightness)
+		s.publishState(deviceName, ctrl)
+		writeJSON(w, brightnessResponse{Brightness: ctrl.Brightness(), Overridden: true})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleTimer(w http.ResponseWriter, r *http.Request, ctrl *controller.Controller) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, ctrl.Schedule())
+	case http.MethodPut:
+		var lt timer.LightTimer
+		if err := json.NewDecoder(r.Body).Decode(&lt); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := lt.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctrl.ReplaceTimer(&lt)
+		ctrl.ClearOverride()
+		writeJSON(w, ctrl.Schedule())
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleSchedule(w http.ResponseWriter, r *http.Request, ctrl *controller.Controller) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	lt := ctrl.Schedule()
+	writeJSON(w, map[string]interface{}{
+		"startHour":     lt.StartHour,
+		"plateauHour":   lt.PlateauHour,
+		"endHour":       lt.EndHour,
+		"brightness":    lt.Brightness,
+		"currentTarget": ctrl.Brightness(),
+		"overridden":    ctrl.Overridden(),
+	})
+}
+
+func (s *Server) publishState(deviceName string, ctrl *controller.Controller) {
+	mqtt, ok := s.mqtt[deviceName]
+	if !ok {
+		return
+	}
+	if err := mqtt.PublishState(ctrl.Brightness()); err != nil {
+		log.Println("Failed to publish MQTT state:", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("Failed to write JSON response:", err)
+	}
+}
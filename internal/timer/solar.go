@@ -0,0 +1,79 @@
+package timer
+
+import (
+	"math"
+	"time"
+)
+
+// polarCase describes the |cos ω0| > 1 edge case, where the sun never
+// crosses the horizon on the given day at the given latitude.
+type polarCase int
+
+const (
+	polarNone    polarCase = iota // normal sunrise/sunset
+	polarAlwaysOn                 // sun never sets (e.g. polar summer)
+	polarAlwaysOff                 // sun never rises (e.g. polar winter)
+)
+
+// sunriseSunset computes sunrise and sunset (as UTC time.Time values) for
+// the given day at (latitude, longitude), using the NOAA/Wikipedia
+// "sunrise equation":
+//
+//	n         = daysSince2000 - longitude/360
+//	J         = 2451545.0 + n
+//	M         = (357.5291 + 0.98560028*n) mod 360          (solar mean anomaly)
+//	C         = 1.9148 sin M + 0.0200 sin 2M + 0.0003 sin 3M (equation of center)
+//	λ         = M + C + 180 + 102.9372                      (ecliptic longitude)
+//	Jtransit  = J + 0.0053 sin M - 0.0069 sin 2λ
+//	sin δ     = sin λ · sin 23.44°                           (declination)
+//	cos ω0    = (sin(-0.83°) - sin φ sin δ)/(cos φ cos δ)    (hour angle)
+//	sunrise   = Jtransit - ω0/360
+//	sunset    = Jtransit + ω0/360
+func sunriseSunset(day time.Time, latitude, longitude float64) (sunrise, sunset time.Time, polar polarCase) {
+	// Julian day numbers are referenced to noon UTC, so daysSince2000 must
+	// be measured from noon too; measuring from midnight shifts every
+	// downstream angle (and thus the computed sunrise/sunset) by 12 hours.
+	noon := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, time.UTC)
+	daysSince2000 := noon.Sub(time.Date(2000, time.January, 1, 12, 0, 0, 0, time.UTC)).Hours() / 24.0
+
+	n := daysSince2000 - longitude/360.0
+	J := 2451545.0 + n
+
+	M := math.Mod(357.5291+0.98560028*n, 360.0)
+	Mrad := toRadians(M)
+	C := 1.9148*math.Sin(Mrad) + 0.0200*math.Sin(2*Mrad) + 0.0003*math.Sin(3*Mrad)
+	lambda := math.Mod(M+C+180+102.9372, 360.0)
+	lambdaRad := toRadians(lambda)
+
+	Jtransit := J + 0.0053*math.Sin(Mrad) - 0.0069*math.Sin(2*lambdaRad)
+
+	sinDelta := math.Sin(lambdaRad) * math.Sin(toRadians(23.44))
+	delta := math.Asin(sinDelta)
+
+	phi := toRadians(latitude)
+	cosOmega0 := (math.Sin(toRadians(-0.83)) - math.Sin(phi)*math.Sin(delta)) / (math.Cos(phi) * math.Cos(delta))
+	if cosOmega0 > 1 {
+		return time.Time{}, time.Time{}, polarAlwaysOff
+	}
+	if cosOmega0 < -1 {
+		return time.Time{}, time.Time{}, polarAlwaysOn
+	}
+	omega0 := toDegrees(math.Acos(cosOmega0))
+
+	Jrise := Jtransit - omega0/360.0
+	Jset := Jtransit + omega0/360.0
+
+	return julianDayToTime(Jrise), julianDayToTime(Jset), polarNone
+}
+
+func toRadians(deg float64) float64 { return deg * math.Pi / 180.0 }
+func toDegrees(rad float64) float64 { return rad * 180.0 / math.Pi }
+
+// julianDayToTime converts a Julian day number (fractional, referenced to
+// noon UTC) to a UTC time.Time.
+func julianDayToTime(jd float64) time.Time {
+	unixSeconds := (jd - 2440587.5) * 86400.0
+	whole := math.Floor(unixSeconds)
+	frac := unixSeconds - whole
+	return time.Unix(int64(whole), int64(frac*1e9)).UTC()
+}
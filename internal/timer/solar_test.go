@@ -0,0 +1,27 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSunriseSunset checks the solar calculation against a known reference:
+// London (51.5074, -0.1278) on the 2026 summer solstice, where sunrise and
+// sunset are well documented (~03:43 UTC and ~20:21 UTC).
+func TestSunriseSunset(t *testing.T) {
+	day := time.Date(2026, time.June, 21, 0, 0, 0, 0, time.UTC)
+	sunrise, sunset, polar := sunriseSunset(day, 51.5074, -0.1278)
+	if polar != polarNone {
+		t.Fatalf("expected a normal sunrise/sunset, got polar case %d", polar)
+	}
+
+	wantSunrise := time.Date(2026, time.June, 21, 3, 43, 0, 0, time.UTC)
+	wantSunset := time.Date(2026, time.June, 21, 20, 21, 0, 0, time.UTC)
+
+	if d := sunrise.Sub(wantSunrise); d < -5*time.Minute || d > 5*time.Minute {
+		t.Errorf("sunrise = %s, want ~%s", sunrise.Format(time.RFC3339), wantSunrise.Format(time.RFC3339))
+	}
+	if d := sunset.Sub(wantSunset); d < -5*time.Minute || d > 5*time.Minute {
+		t.Errorf("sunset = %s, want ~%s", sunset.Format(time.RFC3339), wantSunset.Format(time.RFC3339))
+	}
+}
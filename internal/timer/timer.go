@@ -16,6 +16,25 @@ type LightTimer struct {
 	StepSize      int `json:"StepSize"`
 	PlateauOffset int `json:"PlateauOffset"` // offset in hours applied to sunrise/sunset ramp durations
 	Brightness    int `json:"Brightness"`    // new: maximum brightness at plateau phase
+
+	// Mode selects how the sunrise/sunset edges are determined: "fixed"
+	// (the default, using StartHour/EndHour) or "solar", which computes
+	// real sunrise/sunset for Latitude/Longitude via solar.go. Ramp shape
+	// (StepSize, Brightness, PlateauHour, PlateauOffset) applies in both modes.
+	Mode               string  `json:"Mode"`
+	Latitude           float64 `json:"Latitude"`
+	Longitude          float64 `json:"Longitude"`
+	SolarOffsetMinutes int     `json:"SolarOffsetMinutes"` // shifts sunrise earlier and sunset later by this many minutes
+
+	// InterpolationIntervalSeconds is how often internal/controller
+	// recomputes the ramp target; 0 means "use the default" (60s).
+	InterpolationIntervalSeconds int `json:"InterpolationIntervalSeconds"`
+	// MaxRequestsPerMinute caps how often internal/controller is allowed
+	// to call MarsHydroAPI.SetBrightness; 0 means "use the default" (6).
+	MaxRequestsPerMinute int `json:"MaxRequestsPerMinute"`
+	// MinBrightnessDelta skips a SetBrightness call if the new target differs
+	// from the last committed value by less than this many percentage points.
+	MinBrightnessDelta int `json:"MinBrightnessDelta"`
 }
 
 func (lt *LightTimer) SaveConfig(path string) error {
@@ -38,17 +57,57 @@ func (lt *LightTimer) LoadConfig(path string) error {
 	if err := decoder.Decode(lt); err != nil {
 		return err
 	}
-	// Basic validation: EndHour must be greater than StartHour + PlateauHour
-	if lt.EndHour <= lt.StartHour+lt.PlateauHour {
+	return lt.Validate()
+}
+
+// Validate checks that lt describes a usable ramp: EndHour after
+// StartHour+PlateauHour (skipped in "solar" mode, where the edges come
+// from sunriseSunset instead of StartHour/EndHour), and StepSize a
+// multiple of 5. Called by LoadConfig and by anything else that accepts a
+// LightTimer from outside the process, e.g. internal/server's timer PUT
+// handler.
+func (lt *LightTimer) Validate() error {
+	if lt.Mode != "solar" && lt.EndHour <= lt.StartHour+lt.PlateauHour {
 		return errors.New("invalid configuration: EndHour must be greater than StartHour + PlateauHour")
 	}
-	// New: Validate that StepSize is a multiple of 5.
 	if lt.StepSize%5 != 0 {
 		return errors.New("invalid configuration: StepSize must be a multiple of 5")
 	}
 	return nil
 }
 
+// edgesSeconds returns the sunrise/sunset edges, as seconds since midnight
+// in now's location, that GetExpectedBrightness should ramp between. In
+// "solar" mode these come from sunriseSunset (shifted by
+// SolarOffsetMinutes); any other value of Mode (including "" and "fixed")
+// uses StartHour/EndHour directly.
+//
+// isFallback is true when the edges can't be used directly — currently
+// only the solar polar edge case, where fallback holds the brightness to
+// use for the whole day (0 for "always off", lt.Brightness for "always on").
+func (lt *LightTimer) edgesSeconds(now time.Time) (startSec, endSec, fallback int, isFallback bool) {
+	if lt.Mode != "solar" {
+		return lt.StartHour * 3600, lt.EndHour * 3600, 0, false
+	}
+
+	sunrise, sunset, polar := sunriseSunset(now, lt.Latitude, lt.Longitude)
+	switch polar {
+	case polarAlwaysOn:
+		log.Printf("Solar schedule: sun never sets at latitude %.4f on %s, holding lamp at %d%%", lt.Latitude, now.Format("2006-01-02"), lt.Brightness)
+		return 0, 0, lt.Brightness, true
+	case polarAlwaysOff:
+		log.Printf("Solar schedule: sun never rises at latitude %.4f on %s, holding lamp off", lt.Latitude, now.Format("2006-01-02"))
+		return 0, 0, 0, true
+	}
+
+	offsetSec := lt.SolarOffsetMinutes * 60
+	sunrise = sunrise.In(now.Location()).Add(-time.Duration(offsetSec) * time.Second)
+	sunset = sunset.In(now.Location()).Add(time.Duration(offsetSec) * time.Second)
+
+	toSec := func(t time.Time) int { return t.Hour()*3600 + t.Minute()*60 + t.Second() }
+	return toSec(sunrise), toSec(sunset), 0, false
+}
+
 // GetExpectedBrightness calculates the desired brightness based on current time and configuration.
 // Assumptions:
 // - Before StartHour or after EndHour → 0
@@ -58,9 +117,12 @@ func (lt *LightTimer) LoadConfig(path string) error {
 // The sunrise and sunset durations are adjusted by PlateauOffset (in hours). For example, a negative PlateauOffset
 // shortens sunrise and lengthens sunset, keeping plateau duration constant.
 func (lt *LightTimer) GetExpectedBrightness(now time.Time) int {
+	startSec, endSec, fallback, isFallback := lt.edgesSeconds(now)
+	if isFallback {
+		return fallback
+	}
+
 	nowSec := now.Hour()*3600 + now.Minute()*60 + now.Second()
-	startSec := lt.StartHour * 3600
-	endSec := lt.EndHour * 3600
 	plateauSec := lt.PlateauHour * 3600
 
 	// Baseline ramp duration (in seconds) for sunrise and sunset without offset.
@@ -74,11 +136,15 @@ func (lt *LightTimer) GetExpectedBrightness(now time.Time) int {
 	if nowSec < startSec || nowSec >= endSec {
 		return 0
 	}
-	// Sunrise phase: from startSec to (startSec + sunriseRamp)
+	// Sunrise phase: from startSec to (startSec + sunriseRamp). Brightness
+	// rises linearly from StepSize to lt.Brightness; it's rounded to the
+	// nearest whole percent, not to a multiple of StepSize, so Tick calls
+	// made more often than once per StepSize-sized jump actually move the
+	// brightness (that's the whole point of InterpolationIntervalSeconds).
 	if float64(nowSec) < float64(startSec)+sunriseRamp {
 		fraction := float64(nowSec-startSec) / sunriseRamp
 		brightness := float64(lt.StepSize) + fraction*(float64(lt.Brightness)-float64(lt.StepSize))
-		result := int(math.Round(brightness/float64(lt.StepSize)) * float64(lt.StepSize))
+		result := int(math.Round(brightness))
 		if result > lt.Brightness {
 			result = lt.Brightness
 		}
@@ -92,11 +158,12 @@ func (lt *LightTimer) GetExpectedBrightness(now time.Time) int {
 		return lt.Brightness
 	}
 
-	// Sunset phase: from plateauEnd to endSec.
+	// Sunset phase: from plateauEnd to endSec. Same rounding rule as the
+	// sunrise phase above.
 	if nowSec < endSec {
 		fraction := float64(endSec-nowSec) / sunsetRamp
 		brightness := float64(lt.StepSize) + fraction*(float64(lt.Brightness)-float64(lt.StepSize))
-		result := int(math.Round(brightness/float64(lt.StepSize)) * float64(lt.StepSize))
+		result := int(math.Round(brightness))
 		if result > lt.Brightness {
 			result = lt.Brightness
 		}
@@ -105,6 +172,39 @@ func (lt *LightTimer) GetExpectedBrightness(now time.Time) int {
 	return 0
 }
 
+// Phase reports which part of the ramp now falls into: "sunrise",
+// "plateau", "sunset", or "off". Used for structured logging rather than
+// brightness math, so it re-derives the same edges as GetExpectedBrightness.
+func (lt *LightTimer) Phase(now time.Time) string {
+	startSec, endSec, fallback, isFallback := lt.edgesSeconds(now)
+	if isFallback {
+		if fallback > 0 {
+			return "plateau"
+		}
+		return "off"
+	}
+
+	nowSec := now.Hour()*3600 + now.Minute()*60 + now.Second()
+	if nowSec < startSec || nowSec >= endSec {
+		return "off"
+	}
+
+	plateauSec := lt.PlateauHour * 3600
+	baselineRamp := float64(endSec-startSec-plateauSec) / 2.0
+	offsetSec := float64(lt.PlateauOffset * 3600)
+	sunriseRamp := baselineRamp + offsetSec
+
+	if float64(nowSec) < float64(startSec)+sunriseRamp {
+		return "sunrise"
+	}
+	plateauStart := startSec + int(sunriseRamp)
+	plateauEnd := plateauStart + plateauSec
+	if nowSec >= plateauStart && nowSec < plateauEnd {
+		return "plateau"
+	}
+	return "sunset"
+}
+
 // PrintTimingData prints computed timing information using current config and reference time.
 func (lt *LightTimer) PrintTimingData(now time.Time) {
 	startSec := lt.StartHour * 3600
@@ -1,69 +1,122 @@
 package main
 
 import (
-	"lightcontrol/internal/api"
-	"lightcontrol/internal/config"
 	"log"
+	"net/http"
+	"os"
+	"strings"
 	"time"
+
+	"lightcontrol/internal/api"
+	"lightcontrol/internal/config"
+	"lightcontrol/internal/controller"
+	"lightcontrol/internal/server"
 )
 
 func main() {
-	// Load configurations via new config package.
-	lt, err := config.LoadLightTimerConfig("timer.json")
+	mhAccount, err := config.LoadMHAccountConfig("account.json")
 	if err != nil {
 		log.Fatal(err)
 	}
-	mhAccount, err := config.LoadMHAccountConfig("account.json")
+	deviceConfigs, err := config.LoadDevicesConfig("devices.json")
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Print computed timing data for visualization.
-	lt.PrintTimingData(time.Now())
+	mhapi := api.NewMarsHydroAPI(mhAccount.Email, mhAccount.Password, mhAccount.Wifiname, mhAccount.Timezone, mhAccount.Language)
+	if err := mhapi.Login(); err != nil {
+		log.Fatal("Login error:", err)
+	}
 
-	// Assume initial state unknown; force update on startup.
-	lastBrightness := -1
+	devices, err := mhapi.ListDevices()
+	if err != nil {
+		log.Fatal("Failed to list devices:", err)
+	}
 
-	// Function to update lamp state if needed.
-	updateState := func(state int) {
-		// Always update if lastBrightness is unknown (-1)
-		if lastBrightness != -1 && state == lastBrightness {
-			if state == 0 {
-				log.Println("Lamp remains OFF")
-			} else {
-				log.Printf("Lamp remains ON at %d%% brightness", state)
-			}
-			return
+	controllers := make(map[string]*controller.Controller, len(deviceConfigs))
+	for _, dc := range deviceConfigs {
+		dev, ok := matchDevice(devices, dc.Match)
+		if !ok {
+			log.Printf("No lamp matched devices.json entry %q, skipping", dc.Match)
+			continue
 		}
-		log.Printf("Setting lamp to %d%% brightness", state)
-		mhapi := api.NewMarsHydroAPI(mhAccount.Email, mhAccount.Password, mhAccount.Wifiname, mhAccount.Timezone, mhAccount.Language)
-		if err := mhapi.Login(); err != nil {
-			log.Println("Login error:", err)
-			return
+		groupID := dc.GroupID
+		if groupID == "" {
+			groupID = dev.GroupID
 		}
-		if err := mhapi.SetBrightness(state); err != nil {
-			log.Println("Failed to set brightness:", err)
-			return
+
+		lt := dc.Timer
+		lt.PrintTimingData(time.Now())
+
+		ctrl := controller.New(dc.Match, dev.ID, groupID, mhapi, &lt)
+		controllers[dc.Match] = ctrl
+	}
+	if len(controllers) == 0 {
+		log.Fatal("No devices matched; check devices.json against the account's lamps")
+	}
+
+	mqttClients := map[string]*server.MQTTClient{}
+	if broker := mqttBrokerFromEnv(); broker != "" {
+		for name, ctrl := range controllers {
+			mqttClient, err := server.NewMQTTClient(server.MQTTConfig{
+				Broker:   broker,
+				ClientID: "lightcontrol-" + name,
+				DeviceID: name,
+			}, ctrl)
+			if err != nil {
+				log.Printf("MQTT setup failed for %s, continuing without it: %v", name, err)
+				continue
+			}
+			mqttClients[name] = mqttClient
 		}
-		lastBrightness = state
-		if state == 0 {
-			log.Println("Status: Lamp OFF")
-		} else {
-			log.Printf("Status: Lamp ON at %d%% brightness", state)
+	}
+
+	srv := server.New(controllers, mqttClients)
+	go func() {
+		log.Println("Starting HTTP server on :8080")
+		if err := http.ListenAndServe(":8080", srv.Handler()); err != nil {
+			log.Println("HTTP server stopped:", err)
 		}
+	}()
+
+	// Run one ticker loop per device, each stepping through its own ramp
+	// every InterpolationIntervalSeconds; actual SetBrightness calls share
+	// mhapi's authenticated session and are individually rate limited.
+	log.Println("Configuration loaded, starting event loops...")
+	for _, ctrl := range controllers {
+		go runDevice(ctrl)
 	}
+	select {}
+}
 
-	// On startup: set the lamp to expected state.
-	now := time.Now()
-	expected := lt.GetExpectedBrightness(now)
-	updateState(expected)
+func runDevice(ctrl *controller.Controller) {
+	ctrl.Tick(time.Now())
 
-	// Start a loop that every minute checks and updates the lamp state if needed.
-	ticker := time.NewTicker(1 * time.Minute)
+	ticker := time.NewTicker(ctrl.InterpolationInterval())
 	defer ticker.Stop()
-	log.Println("Configuration loaded, starting event loop...")
 	for now := range ticker.C {
-		expected := lt.GetExpectedBrightness(now)
-		updateState(expected)
+		ctrl.Tick(now)
+	}
+}
+
+// matchDevice finds the cloud device identified by match: either its exact
+// ID, or (failing that) a substring of its deviceName.
+func matchDevice(devices []api.Device, match string) (api.Device, bool) {
+	for _, d := range devices {
+		if d.ID == match {
+			return d, true
+		}
 	}
+	for _, d := range devices {
+		if strings.Contains(d.Name, match) {
+			return d, true
+		}
+	}
+	return api.Device{}, false
+}
+
+// mqttBrokerFromEnv returns the configured MQTT broker URL, or "" to run
+// HTTP-only. MQTT is opt-in since most installs don't run a broker.
+func mqttBrokerFromEnv() string {
+	return os.Getenv("MQTT_BROKER")
 }